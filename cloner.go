@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// CloneScope narrows what BareClone/MirrorPush pull down and push, for
+// forking huge repositories without their full history.
+type CloneScope struct {
+	Depth         int    // 0 means full history
+	SingleBranch  bool   // only include DefaultBranch (and, for MirrorPush/GitCLICloner, tags reachable from it)
+	Filter        string // e.g. "blob:none"; "" disables partial clone
+	DefaultBranch string // required when SingleBranch is set
+}
+
+// Cloner abstracts the clone/push/remote operations used to build a
+// private fork, so the pipeline can run in-process via go-git instead of
+// shelling out to the system git binary.
+type Cloner interface {
+	BareClone(ctx context.Context, url, dir string, scope CloneScope) error
+	MirrorPush(ctx context.Context, dir, url string, scope CloneScope) error
+	AddRemote(ctx context.Context, dir, name, url string) error
+}
+
+// newCloner returns the in-process go-git Cloner, or the system-git
+// Cloner when useGitCLI is set (e.g. for users relying on SSH signing or
+// credential helpers go-git doesn't support).
+func newCloner(useGitCLI bool) Cloner {
+	if useGitCLI {
+		return GitCLICloner{}
+	}
+	return GoGitCloner{}
+}
+
+// GoGitCloner implements Cloner in-process with go-git, streaming
+// progress to stderr and honoring ctx cancellation.
+type GoGitCloner struct{}
+
+func (GoGitCloner) BareClone(ctx context.Context, url, dir string, scope CloneScope) error {
+	if scope.Filter != "" {
+		return fmt.Errorf("partial clone filters (--filter) require --use-git-cli; go-git doesn't support them")
+	}
+
+	opts := &git.CloneOptions{
+		URL:          url,
+		Progress:     os.Stderr,
+		Depth:        scope.Depth,
+		SingleBranch: scope.SingleBranch,
+	}
+	if scope.SingleBranch && scope.DefaultBranch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(scope.DefaultBranch)
+		// go-git's AllTags default unconditionally fetches every tag (and
+		// whatever history those tags pull in) regardless of SingleBranch,
+		// defeating the point of narrowing the clone. go-git only follows
+		// tags (TagFollowing) for wildcard refspecs, which a single-branch
+		// fetch never uses, so there's no way to ask it for just the tags
+		// reachable from DefaultBranch here; use --use-git-cli for exact
+		// parity with a real single-branch `git clone`.
+		opts.Tags = git.NoTags
+	}
+
+	_, err := git.PlainCloneContext(ctx, dir, true, opts)
+	return wrapAuthError(err)
+}
+
+func (GoGitCloner) MirrorPush(ctx context.Context, dir, url string, scope CloneScope) error {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return err
+	}
+
+	const pushRemote = "private-fork-mirror"
+	_ = repo.DeleteRemote(pushRemote)
+	remote, err := repo.CreateRemote(&config.RemoteConfig{
+		Name: pushRemote,
+		URLs: []string{url},
+	})
+	if err != nil {
+		return err
+	}
+	defer repo.DeleteRemote(pushRemote)
+
+	refSpecs, err := mirrorRefSpecs(repo, scope)
+	if err != nil {
+		return err
+	}
+
+	err = remote.PushContext(ctx, &git.PushOptions{
+		RefSpecs: refSpecs,
+		Prune:    !scope.SingleBranch,
+		Progress: os.Stderr,
+	})
+	if errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil
+	}
+	return wrapAuthError(err)
+}
+
+// wrapAuthError adds a pointer to --use-git-cli when go-git fails because
+// it has no way to consult the system's credential helper or SSH agent
+// the way shelling out to the real git binary does.
+func wrapAuthError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrAuthorizationFailed) {
+		return fmt.Errorf("%w (go-git doesn't use your git credential helper or SSH agent; retry with --use-git-cli)", err)
+	}
+	return err
+}
+
+// mirrorRefSpecs returns the refspecs MirrorPush should push: everything
+// for a full mirror, or just the default branch plus tags reachable from
+// it when scope.SingleBranch narrows the fork.
+func mirrorRefSpecs(repo *git.Repository, scope CloneScope) ([]config.RefSpec, error) {
+	if !scope.SingleBranch {
+		return []config.RefSpec{"+refs/*:refs/*"}, nil
+	}
+
+	branchRef := fmt.Sprintf("refs/heads/%s:refs/heads/%s", scope.DefaultBranch, scope.DefaultBranch)
+	refSpecs := []config.RefSpec{config.RefSpec(branchRef)}
+
+	head, err := repo.Reference(plumbing.NewBranchReferenceName(scope.DefaultBranch), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve default branch %q: %w", scope.DefaultBranch, err)
+	}
+
+	tags, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	defer tags.Close()
+
+	err = tags.ForEach(func(tagRef *plumbing.Reference) error {
+		reachable, err := isAncestor(repo, tagRef.Hash(), head.Hash())
+		if err != nil || !reachable {
+			return nil
+		}
+		name := tagRef.Name().String()
+		refSpecs = append(refSpecs, config.RefSpec(fmt.Sprintf("%s:%s", name, name)))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refSpecs, nil
+}
+
+// isAncestor reports whether commit is reachable from tip, resolving tag
+// objects to the commit they point at.
+func isAncestor(repo *git.Repository, commit, tip plumbing.Hash) (bool, error) {
+	commitObj, err := repo.CommitObject(commit)
+	if err != nil {
+		// Not a commit (e.g. an annotated tag object); resolve via the
+		// tag, falling back to "not reachable" if that fails too.
+		tagObj, tagErr := repo.TagObject(commit)
+		if tagErr != nil {
+			return false, nil
+		}
+		target, tagErr := tagObj.Commit()
+		if tagErr != nil {
+			return false, nil
+		}
+		commitObj = target
+	}
+
+	tipObj, err := repo.CommitObject(tip)
+	if err != nil {
+		return false, err
+	}
+
+	return commitObj.IsAncestor(tipObj)
+}
+
+func (GoGitCloner) AddRemote(ctx context.Context, dir, name, url string) error {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return err
+	}
+	_, err = repo.CreateRemote(&config.RemoteConfig{
+		Name: name,
+		URLs: []string{url},
+	})
+	return err
+}
+
+// GitCLICloner implements Cloner by shelling out to the system git
+// binary, for users who need something go-git doesn't support (SSH
+// commit signing, custom credential helpers, Git LFS).
+type GitCLICloner struct{}
+
+func (GitCLICloner) BareClone(ctx context.Context, url, dir string, scope CloneScope) error {
+	args := []string{"clone", "--bare"}
+	if scope.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(scope.Depth))
+	}
+	if scope.SingleBranch {
+		args = append(args, "--single-branch")
+		if scope.DefaultBranch != "" {
+			args = append(args, "--branch", scope.DefaultBranch)
+		}
+	}
+	if scope.Filter != "" {
+		args = append(args, "--filter="+scope.Filter)
+	}
+	args = append(args, url, dir)
+
+	return execGitContext(ctx, args...)
+}
+
+func (GitCLICloner) MirrorPush(ctx context.Context, dir, url string, scope CloneScope) error {
+	if !scope.SingleBranch {
+		return execGitContext(ctx, "-C", dir, "push", "--mirror", url)
+	}
+
+	refSpecs, err := gitCLIMirrorRefSpecs(ctx, dir, scope)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"-C", dir, "push", url}, refSpecs...)
+	return execGitContext(ctx, args...)
+}
+
+// gitCLIMirrorRefSpecs lists the default branch plus every tag reachable
+// from it, for narrowing `git push` when the fork is --single-branch.
+func gitCLIMirrorRefSpecs(ctx context.Context, dir string, scope CloneScope) ([]string, error) {
+	refSpecs := []string{fmt.Sprintf("refs/heads/%s:refs/heads/%s", scope.DefaultBranch, scope.DefaultBranch)}
+
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "tag", "--merged", scope.DefaultBranch).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags reachable from %s: %w", scope.DefaultBranch, err)
+	}
+
+	for _, tag := range strings.Fields(string(out)) {
+		ref := fmt.Sprintf("refs/tags/%s:refs/tags/%s", tag, tag)
+		refSpecs = append(refSpecs, ref)
+	}
+
+	return refSpecs, nil
+}
+
+func (GitCLICloner) AddRemote(ctx context.Context, dir, name, url string) error {
+	return execGitContext(ctx, "-C", dir, "remote", "add", name, url)
+}
+
+func execGitContext(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
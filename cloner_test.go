@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// newTestCommit writes a commit object with an empty tree directly into
+// repo's storer, bypassing the worktree so tests don't need a real
+// filesystem to check anything out into.
+func newTestCommit(t *testing.T, repo *git.Repository, parent plumbing.Hash, message string) plumbing.Hash {
+	t.Helper()
+
+	treeObj := repo.Storer.NewEncodedObject()
+	if err := (&object.Tree{}).Encode(treeObj); err != nil {
+		t.Fatalf("encode tree: %v", err)
+	}
+	treeHash, err := repo.Storer.SetEncodedObject(treeObj)
+	if err != nil {
+		t.Fatalf("store tree: %v", err)
+	}
+
+	sig := object.Signature{Name: "tester", Email: "tester@example.com", When: time.Unix(1700000000, 0)}
+	commit := &object.Commit{
+		Author:    sig,
+		Committer: sig,
+		Message:   message,
+		TreeHash:  treeHash,
+	}
+	if parent != plumbing.ZeroHash {
+		commit.ParentHashes = []plumbing.Hash{parent}
+	}
+
+	commitObj := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(commitObj); err != nil {
+		t.Fatalf("encode commit: %v", err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(commitObj)
+	if err != nil {
+		t.Fatalf("store commit: %v", err)
+	}
+	return hash
+}
+
+func setRef(t *testing.T, repo *git.Repository, name plumbing.ReferenceName, hash plumbing.Hash) {
+	t.Helper()
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(name, hash)); err != nil {
+		t.Fatalf("set reference %s: %v", name, err)
+	}
+}
+
+func refSpecStrings(refSpecs []config.RefSpec) []string {
+	out := make([]string, len(refSpecs))
+	for i, rs := range refSpecs {
+		out[i] = rs.String()
+	}
+	return out
+}
+
+func containsRefSpec(refSpecs []config.RefSpec, want string) bool {
+	for _, got := range refSpecStrings(refSpecs) {
+		if got == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMirrorRefSpecsFullMirror(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatalf("git.Init: %v", err)
+	}
+
+	refSpecs, err := mirrorRefSpecs(repo, CloneScope{})
+	if err != nil {
+		t.Fatalf("mirrorRefSpecs: %v", err)
+	}
+	if !containsRefSpec(refSpecs, "+refs/*:refs/*") {
+		t.Fatalf("expected a full mirror refspec, got %v", refSpecStrings(refSpecs))
+	}
+}
+
+func TestMirrorRefSpecsSingleBranch(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatalf("git.Init: %v", err)
+	}
+
+	mainCommit := newTestCommit(t, repo, plumbing.ZeroHash, "initial commit")
+	setRef(t, repo, plumbing.NewBranchReferenceName("main"), mainCommit)
+	setRef(t, repo, plumbing.NewTagReferenceName("v1"), mainCommit)
+
+	// A commit and tag that only exist on an unrelated branch must not be
+	// pulled in when narrowing to "main".
+	featureCommit := newTestCommit(t, repo, plumbing.ZeroHash, "unrelated feature work")
+	setRef(t, repo, plumbing.NewBranchReferenceName("feature"), featureCommit)
+	setRef(t, repo, plumbing.NewTagReferenceName("v2-unreleased"), featureCommit)
+
+	refSpecs, err := mirrorRefSpecs(repo, CloneScope{SingleBranch: true, DefaultBranch: "main"})
+	if err != nil {
+		t.Fatalf("mirrorRefSpecs: %v", err)
+	}
+
+	if !containsRefSpec(refSpecs, "refs/heads/main:refs/heads/main") {
+		t.Errorf("expected default branch refspec, got %v", refSpecStrings(refSpecs))
+	}
+	if !containsRefSpec(refSpecs, "refs/tags/v1:refs/tags/v1") {
+		t.Errorf("expected reachable tag v1 to be included, got %v", refSpecStrings(refSpecs))
+	}
+	if containsRefSpec(refSpecs, "refs/tags/v2-unreleased:refs/tags/v2-unreleased") {
+		t.Errorf("unreachable tag v2-unreleased should not be included, got %v", refSpecStrings(refSpecs))
+	}
+	if containsRefSpec(refSpecs, "+refs/*:refs/*") {
+		t.Errorf("single-branch scope should not fall back to a full mirror, got %v", refSpecStrings(refSpecs))
+	}
+}
+
+// TestGoGitClonerBareCloneSingleBranchSkipsTags exercises the actual fetch
+// path (not just refspec computation) by serving a source repo over go-git's
+// in-memory server, registered in place of the "file" transport, and cloning
+// it through go-git's real client fetch code. It
+// guards against BareClone silently reverting to go-git's AllTags default,
+// which would pull down every tag -- and the history behind it -- defeating
+// the point of --single-branch.
+func TestGoGitClonerBareCloneSingleBranchSkipsTags(t *testing.T) {
+	srcStorer := memory.NewStorage()
+	srcRepo, err := git.Init(srcStorer, memfs.New())
+	if err != nil {
+		t.Fatalf("git.Init: %v", err)
+	}
+
+	mainCommit := newTestCommit(t, srcRepo, plumbing.ZeroHash, "initial commit")
+	setRef(t, srcRepo, plumbing.NewBranchReferenceName("main"), mainCommit)
+	setRef(t, srcRepo, plumbing.NewTagReferenceName("v1"), mainCommit)
+	setRef(t, srcRepo, plumbing.HEAD, mainCommit)
+	if err := srcStorer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main"))); err != nil {
+		t.Fatalf("set HEAD: %v", err)
+	}
+
+	// A commit and tag that only exist on an unrelated branch must never be
+	// fetched when narrowing to "main".
+	featureCommit := newTestCommit(t, srcRepo, plumbing.ZeroHash, "unrelated feature work")
+	setRef(t, srcRepo, plumbing.NewBranchReferenceName("feature"), featureCommit)
+	setRef(t, srcRepo, plumbing.NewTagReferenceName("v2-unreleased"), featureCommit)
+
+	endpoint, err := transport.NewEndpoint("/in-memory-source.git")
+	if err != nil {
+		t.Fatalf("transport.NewEndpoint: %v", err)
+	}
+
+	loader := server.MapLoader{endpoint.String(): srcStorer}
+	previous := client.Protocols["file"]
+	client.Protocols["file"] = server.NewServer(loader)
+	defer func() { client.Protocols["file"] = previous }()
+
+	dir := t.TempDir()
+	scope := CloneScope{SingleBranch: true, DefaultBranch: "main"}
+	if err := (GoGitCloner{}).BareClone(context.Background(), endpoint.String(), dir, scope); err != nil {
+		t.Fatalf("BareClone: %v", err)
+	}
+
+	dstRepo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("git.PlainOpen: %v", err)
+	}
+
+	// go-git can't selectively follow tags on a non-wildcard single-branch
+	// fetch (see the comment in BareClone), so neither tag should come
+	// across -- not even the one reachable from main. That's the tradeoff
+	// for not pulling down v2-unreleased and the feature history behind it.
+	if _, err := dstRepo.Tag("v1"); err == nil {
+		t.Errorf("tag v1 should not have been fetched on a single-branch clone")
+	}
+	if _, err := dstRepo.Tag("v2-unreleased"); err == nil {
+		t.Errorf("unreachable tag v2-unreleased should not have been fetched")
+	}
+	if _, err := dstRepo.CommitObject(featureCommit); err == nil {
+		t.Errorf("commit reachable only from the unrelated feature branch should not have been fetched")
+	}
+	if _, err := dstRepo.CommitObject(mainCommit); err != nil {
+		t.Errorf("expected main's commit to be fetched: %v", err)
+	}
+}
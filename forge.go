@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+
+	"github.com/cli/go-gh"
+)
+
+// ForgeProvider abstracts the Git hosting service a repository is forked
+// from and to, so privateForkRun isn't hardwired to GitHub.
+type ForgeProvider interface {
+	// CreateRepo creates a new private repository named name (in
+	// "owner/repo" form) and returns its SSH clone URL.
+	CreateRepo(name string, opts *PrivateForkOptions) (string, error)
+	// CurrentUser returns the login of the authenticated user.
+	CurrentUser() (string, error)
+	// RepoURL returns the SSH clone URL for owner/name on this forge.
+	RepoURL(owner, name string) string
+	// RepoHTTPURL returns the HTTPS clone URL for owner/name on this forge.
+	RepoHTTPURL(owner, name string) string
+}
+
+// newForgeProvider resolves a --forge value (or a guess derived from the
+// source repository's host) to a ForgeProvider.
+func newForgeProvider(forge string) (ForgeProvider, error) {
+	switch forge {
+	case "", "github":
+		return &GitHubProvider{}, nil
+	case "gitlab":
+		return &GitLabProvider{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported --forge %q (want \"github\" or \"gitlab\")", forge)
+	}
+}
+
+// forgeFromHost guesses which forge a source repository lives on by
+// inspecting its URL, falling back to GitHub for bare OWNER/REPO names.
+func forgeFromHost(sourceRepo string) string {
+	host := ""
+	if strings.HasPrefix(sourceRepo, "http://") || strings.HasPrefix(sourceRepo, "https://") {
+		if u, err := url.Parse(sourceRepo); err == nil {
+			host = u.Host
+		}
+	} else if strings.HasPrefix(sourceRepo, "git@") {
+		host = strings.TrimPrefix(strings.Split(sourceRepo, ":")[0], "git@")
+	}
+
+	if strings.Contains(host, "gitlab") {
+		return "gitlab"
+	}
+	return "github"
+}
+
+// GitHubProvider implements ForgeProvider on top of the gh CLI, preserving
+// the tool's original behavior.
+type GitHubProvider struct{}
+
+func (p *GitHubProvider) CreateRepo(name string, opts *PrivateForkOptions) (string, error) {
+	createArgs := []string{"repo", "create", name, "--private"}
+	if opts.DefaultBranchOnly {
+		createArgs = append(createArgs, "--default-branch-only")
+	}
+
+	_, stderr, err := gh.Exec(createArgs...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create private repository: %s", stderr.String())
+	}
+
+	owner, repo, _ := strings.Cut(name, "/")
+	return p.RepoURL(owner, repo), nil
+}
+
+func (p *GitHubProvider) CurrentUser() (string, error) {
+	stdout, stderr, err := gh.Exec("api", "user", "--jq", ".login")
+	if err != nil {
+		// Fallback to getting it from git config
+		stdout, stderr, err = gh.Exec("config", "get", "github.user")
+		if err != nil {
+			return "", fmt.Errorf("unable to determine current GitHub user: %s", stderr.String())
+		}
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func (p *GitHubProvider) RepoURL(owner, name string) string {
+	return fmt.Sprintf("git@github.com:%s/%s.git", owner, name)
+}
+
+func (p *GitHubProvider) RepoHTTPURL(owner, name string) string {
+	return fmt.Sprintf("https://github.com/%s/%s.git", owner, name)
+}
+
+// GitLabProvider implements ForgeProvider on top of the glab CLI, mirroring
+// how GitHubProvider shells out to gh.
+type GitLabProvider struct{}
+
+func (p *GitLabProvider) CreateRepo(name string, opts *PrivateForkOptions) (string, error) {
+	createArgs := []string{"repo", "create", name, "--private"}
+
+	if _, stderr, err := execGlab(createArgs...); err != nil {
+		return "", fmt.Errorf("failed to create private repository: %s", stderr)
+	}
+
+	owner, repo, _ := strings.Cut(name, "/")
+	return p.RepoURL(owner, repo), nil
+}
+
+func (p *GitLabProvider) CurrentUser() (string, error) {
+	stdout, stderr, err := execGlab("api", "user", "--jq", ".username")
+	if err != nil {
+		return "", fmt.Errorf("unable to determine current GitLab user: %s", stderr)
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+func (p *GitLabProvider) RepoURL(owner, name string) string {
+	return fmt.Sprintf("git@gitlab.com:%s/%s.git", owner, name)
+}
+
+func (p *GitLabProvider) RepoHTTPURL(owner, name string) string {
+	return fmt.Sprintf("https://gitlab.com/%s/%s.git", owner, name)
+}
+
+// execGlab runs the glab CLI, the GitLab counterpart of gh.Exec.
+func execGlab(args ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("glab", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
@@ -1,14 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"os"
 	"os/exec"
 	"strings"
 
+	"github.com/AlecAivazis/survey/v2"
 	"github.com/MakeNowJust/heredoc"
 	"github.com/cli/go-gh"
+	"github.com/cli/go-gh/pkg/term"
 	"github.com/spf13/cobra"
 )
 
@@ -25,6 +28,13 @@ type PrivateForkOptions struct {
 	Organization     string
 	ForkName         string
 	DefaultBranchOnly bool
+	Rename           bool
+	LFS              bool
+	Forge            string
+	UseGitCLI        bool
+	Depth            int
+	SingleBranch     bool
+	Filter           string
 }
 
 func execGit(args ...string) error {
@@ -78,6 +88,15 @@ func main() {
 	rootCmd.Flags().StringVar(&opts.Organization, "org", "", "Create the fork in an organization")
 	rootCmd.Flags().StringVar(&opts.ForkName, "fork-name", "", "Rename the forked repository")
 	rootCmd.Flags().BoolVar(&opts.DefaultBranchOnly, "default-branch-only", false, "Only include the default branch in the fork")
+	rootCmd.Flags().BoolVar(&opts.Rename, "rename", true, "Rename an existing origin remote to upstream when adding the fork's remote")
+	rootCmd.Flags().BoolVar(&opts.LFS, "lfs", false, "Mirror Git LFS objects into the private fork (auto-detected if not set)")
+	rootCmd.Flags().StringVar(&opts.Forge, "forge", "", "Git forge to fork on: github or gitlab (guessed from the source repository if not set)")
+	rootCmd.Flags().BoolVar(&opts.UseGitCLI, "use-git-cli", false, "Shell out to the system git binary instead of cloning in-process")
+	rootCmd.Flags().IntVar(&opts.Depth, "depth", 0, "Create a shallow clone with history truncated to the given number of commits")
+	rootCmd.Flags().BoolVar(&opts.SingleBranch, "single-branch", false, "Only fork the default branch (with --use-git-cli, also the tags reachable from it; the in-process clone fetches no tags)")
+	rootCmd.Flags().StringVar(&opts.Filter, "filter", "", `Use a partial clone filter (e.g. "blob:none") to defer downloading blobs`)
+
+	rootCmd.AddCommand(newSyncCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
@@ -106,65 +125,144 @@ func privateForkRun(opts *PrivateForkOptions) error {
 		return err
 	}
 
-	// Create bare clone
-	fmt.Printf("Creating bare clone of %s...\n", sourceRepo)
-	if err := execGit("clone", "--bare", sourceRepo); err != nil {
-		return fmt.Errorf("failed to create bare clone: %w", err)
+	forgeName := opts.Forge
+	if forgeName == "" {
+		forgeName = forgeFromHost(sourceRepo)
+	}
+	forge, err := newForgeProvider(forgeName)
+	if err != nil {
+		return err
+	}
+	cloner := newCloner(opts.UseGitCLI)
+	ctx := context.Background()
+
+	scope := CloneScope{Depth: opts.Depth, SingleBranch: opts.SingleBranch, Filter: opts.Filter}
+	if scope.SingleBranch {
+		defaultBranch, err := remoteDefaultBranch(ctx, sourceRepo)
+		if err != nil {
+			return err
+		}
+		scope.DefaultBranch = defaultBranch
 	}
 
-	// Get into the bare repository directory
+	// Bare-clone into a directory named after the repository being forked.
 	repoDir := repoToFork + ".git"
 	repoName := strings.Split(repoDir, "/")[1]
 	defer cleanup(repoName)
 
+	fmt.Printf("Creating bare clone of %s...\n", sourceRepo)
+	if err := cloner.BareClone(ctx, sourceRepo, repoName, scope); err != nil {
+		return fmt.Errorf("failed to create bare clone: %w", err)
+	}
+
 	// Create new private repository
-	destRepo := determineDestRepo(repoToFork, opts.Organization, opts.ForkName)
+	destRepo, err := determineDestRepo(repoToFork, opts.Organization, opts.ForkName, forge)
+	if err != nil {
+		return err
+	}
 	fmt.Printf("Creating private repository %s...\n", destRepo)
 
-	createArgs := []string{"repo", "create", destRepo, "--private"}
-	if opts.DefaultBranchOnly {
-		createArgs = append(createArgs, "--default-branch-only")
+	destURL, err := forge.CreateRepo(destRepo, opts)
+	if err != nil {
+		return err
 	}
 
-	_, stderr, err := gh.Exec(createArgs...)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", stderr.String())
-		return fmt.Errorf("failed to create private repository: %w", err)
+	// Mirror Git LFS objects alongside the regular history, if requested
+	// or detected.
+	useLFS := opts.LFS || repoUsesLFS(repoName)
+	if useLFS {
+		if err := requireGitLFS(); err != nil {
+			return err
+		}
+
+		fmt.Println("Fetching LFS objects...")
+		if err := execGit("-C", repoName, "lfs", "fetch", "--all", "origin"); err != nil {
+			return fmt.Errorf("failed to fetch LFS objects: %w", err)
+		}
 	}
 
 	// Push to new private repository
 	fmt.Println("Pushing to private repository...")
-	pushCmd := exec.Command("git", "-C", repoName, "push", "--mirror", fmt.Sprintf("git@github.com:%s.git", destRepo))
-	pushCmd.Stdout = os.Stdout
-	pushCmd.Stderr = os.Stderr
-	if err := pushCmd.Run(); err != nil {
+	if err := cloner.MirrorPush(ctx, repoName, destURL, scope); err != nil {
 		return fmt.Errorf("failed to push to private repository: %w", err)
 	}
 
+	if useLFS {
+		fmt.Println("Pushing LFS objects...")
+		if err := execGit("-C", repoName, "lfs", "push", "--all", destURL); err != nil {
+			return fmt.Errorf("failed to push LFS objects: %w", err)
+		}
+	}
+
+	// In a TTY, fall back to interactive prompts when the caller didn't
+	// already tell us what to do via --clone/--remote, mirroring the
+	// PromptClone/PromptRemote flow in `gh repo fork`. IsTerminalOutput
+	// only checks stdout; also check stdin so a piped stdin (e.g. in CI)
+	// doesn't hang waiting on a survey prompt it can never read an answer
+	// from.
+	if !opts.Clone && !opts.Remote && term.FromEnv().IsTerminalOutput() && term.IsTerminal(os.Stdin) {
+		if err := promptCloneAndRemote(opts); err != nil {
+			return err
+		}
+	}
+
 	// Handle cloning if requested
-	if opts.Clone {
-		err = handleClone(destRepo, repoToFork, opts)
+	if opts.Clone || opts.PromptClone {
+		err = handleClone(ctx, destRepo, repoToFork, opts, forge, cloner)
 		if err != nil {
 			return err
 		}
 	}
 
 	// Handle remote if requested
-	if opts.Remote {
-		err = handleRemote(destRepo, opts.RemoteName)
+	if opts.Remote || opts.PromptRemote {
+		err = handleRemote(destRepo, opts.RemoteName, opts.Rename, forge)
 		if err != nil {
 			return err
 		}
 	}
 
+	if err := saveForkRecord(ForkRecord{Source: repoToFork, Dest: destRepo, Forge: forgeName}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record fork for later sync: %v\n", err)
+	}
+
 	fmt.Printf("✓ Created private fork %s\n", destRepo)
 	return nil
 }
 
-func handleClone(destRepo, sourceRepo string, opts *PrivateForkOptions) error {
+// promptCloneAndRemote asks the user whether the new private fork should
+// be cloned and, only if they decline, whether it should be added as a
+// remote instead; it never sets both PromptClone and PromptRemote. It mirrors
+// the survey used by `gh repo fork` when run interactively without
+// --clone/--remote.
+func promptCloneAndRemote(opts *PrivateForkOptions) error {
+	if err := survey.AskOne(&survey.Confirm{
+		Message: "Would you like to clone the fork?",
+		Default: true,
+	}, &opts.PromptClone); err != nil {
+		return fmt.Errorf("failed to prompt for clone: %w", err)
+	}
+
+	if !opts.PromptClone {
+		if err := survey.AskOne(&survey.Confirm{
+			Message: "Would you like to add a remote for the fork?",
+			Default: true,
+		}, &opts.PromptRemote); err != nil {
+			return fmt.Errorf("failed to prompt for remote: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func handleClone(ctx context.Context, destRepo, sourceRepo string, opts *PrivateForkOptions, forge ForgeProvider, cloner Cloner) error {
 	fmt.Printf("Cloning fork %s...\n", destRepo)
-	cloneURL := fmt.Sprintf("git@github.com:%s.git", destRepo)
+	destOwner, destName, _ := strings.Cut(destRepo, "/")
+	cloneURL := forge.RepoURL(destOwner, destName)
 
+	// A working-tree clone with arbitrary extra `git clone` flags isn't
+	// something Cloner's BareClone models, so this always shells out to
+	// the system git binary regardless of --use-git-cli.
 	args := append([]string{"clone"}, opts.GitArgs...)
 	args = append(args, cloneURL)
 
@@ -173,18 +271,18 @@ func handleClone(destRepo, sourceRepo string, opts *PrivateForkOptions) error {
 	}
 
 	// Add upstream remote
-	repoName := strings.Split(destRepo, "/")[1]
-	upstreamURL := fmt.Sprintf("https://github.com/%s.git", sourceRepo)
+	repoName := destName
+	srcOwner, srcName, _ := strings.Cut(sourceRepo, "/")
+	upstreamURL := forge.RepoHTTPURL(srcOwner, srcName)
 
-	args = []string{"-C", repoName, "remote", "add", "upstream", upstreamURL}
-	if err := execGit(args...); err != nil {
+	if err := cloner.AddRemote(ctx, repoName, "upstream", upstreamURL); err != nil {
 		return fmt.Errorf("failed to add upstream remote: %w", err)
 	}
 
 	return nil
 }
 
-func handleRemote(destRepo, remoteName string) error {
+func handleRemote(destRepo, remoteName string, rename bool, forge ForgeProvider) error {
 	// Check if remote exists
 	output, err := exec.Command("git", "remote").Output()
 	if err != nil {
@@ -194,6 +292,9 @@ func handleRemote(destRepo, remoteName string) error {
 	remotes := strings.Split(strings.TrimSpace(string(output)), "\n")
 	for _, remote := range remotes {
 		if remote == remoteName {
+			if !rename {
+				return fmt.Errorf("remote %q already exists; pass --rename to rename it to upstream", remoteName)
+			}
 			// Rename existing remote to upstream
 			if err := execGit("remote", "rename", remoteName, "upstream"); err != nil {
 				return fmt.Errorf("failed to rename remote: %w", err)
@@ -203,13 +304,35 @@ func handleRemote(destRepo, remoteName string) error {
 	}
 
 	// Add new remote
-	if err := execGit("remote", "add", remoteName, fmt.Sprintf("https://github.com/%s.git", destRepo)); err != nil {
+	destOwner, destName, _ := strings.Cut(destRepo, "/")
+	if err := execGit("remote", "add", remoteName, forge.RepoHTTPURL(destOwner, destName)); err != nil {
 		return fmt.Errorf("failed to add remote: %w", err)
 	}
 
 	return nil
 }
 
+// remoteDefaultBranch asks the remote which branch HEAD points at, so
+// --single-branch knows what to keep without first fetching everything.
+func remoteDefaultBranch(ctx context.Context, url string) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "ls-remote", "--symref", url, "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine default branch of %s: %w", url, err)
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if !strings.HasPrefix(line, "ref: ") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) >= 2 {
+			return strings.TrimPrefix(fields[1], "refs/heads/"), nil
+		}
+	}
+
+	return "", fmt.Errorf("could not determine default branch of %s", url)
+}
+
 func parseRepository(repo string) (string, error) {
 	if strings.HasPrefix(repo, "http://") || strings.HasPrefix(repo, "https://") {
 		u, err := url.Parse(repo)
@@ -238,7 +361,7 @@ func parseRepository(repo string) (string, error) {
 	return repo, nil
 }
 
-func determineDestRepo(sourceRepo, org, forkName string) string {
+func determineDestRepo(sourceRepo, org, forkName string, forge ForgeProvider) (string, error) {
 	parts := strings.Split(sourceRepo, "/")
 	repoName := parts[1]
 
@@ -247,21 +370,34 @@ func determineDestRepo(sourceRepo, org, forkName string) string {
 	}
 
 	if org != "" {
-		return fmt.Sprintf("%s/%s", org, repoName)
+		return fmt.Sprintf("%s/%s", org, repoName), nil
 	}
 
-	// Get current user
-	stdout, stderr, err := gh.Exec("api", "user", "--jq", ".login")
+	user, err := forge.CurrentUser()
 	if err != nil {
-		// Fallback to getting it from git config
-		stdout, stderr, err = gh.Exec("config", "get", "github.user")
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %s\n", stderr.String())
-			return fmt.Sprintf("OWNER/%s", repoName)
-		}
+		return "", err
 	}
 
-	return fmt.Sprintf("%s/%s", strings.TrimSpace(stdout.String()), repoName)
+	return fmt.Sprintf("%s/%s", user, repoName), nil
+}
+
+// repoUsesLFS reports whether the bare clone at repoName tracks any paths
+// with a Git LFS filter in its .gitattributes.
+func repoUsesLFS(repoName string) bool {
+	out, err := exec.Command("git", "-C", repoName, "show", "HEAD:.gitattributes").Output()
+	if err != nil {
+		// No .gitattributes at HEAD, or no commits yet.
+		return false
+	}
+	return strings.Contains(string(out), "filter=lfs")
+}
+
+// requireGitLFS returns a clear error if the git-lfs binary isn't on PATH.
+func requireGitLFS() error {
+	if _, err := exec.LookPath("git-lfs"); err != nil {
+		return fmt.Errorf("git-lfs is required to mirror LFS objects but was not found on PATH: %w", err)
+	}
+	return nil
 }
 
 func cleanup(repoDir string) {
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ForkRecord describes a private fork created by this tool, persisted so
+// the sync subcommand can find it later without requiring a local clone.
+type ForkRecord struct {
+	Source string `json:"source"` // upstream repository, as OWNER/REPO
+	Dest   string `json:"dest"`   // private fork, as OWNER/REPO
+	Forge  string `json:"forge"`  // forge both repositories live on
+}
+
+// stateFilePath returns the path to the local JSON file that records
+// forks created by this tool.
+func stateFilePath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "gh-private-fork", "forks.json"), nil
+}
+
+// loadForkRecords reads the fork registry, returning an empty slice if it
+// doesn't exist yet.
+func loadForkRecords() ([]ForkRecord, error) {
+	path, err := stateFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var records []ForkRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// saveForkRecord appends rec to the fork registry, creating it if needed.
+func saveForkRecord(rec ForkRecord) error {
+	path, err := stateFilePath()
+	if err != nil {
+		return err
+	}
+
+	records, err := loadForkRecords()
+	if err != nil {
+		return err
+	}
+	records = append(records, rec)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
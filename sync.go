@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+// SyncOptions configures the `private-fork sync` subcommand.
+type SyncOptions struct {
+	Source   string
+	Interval int
+	All      bool
+}
+
+func newSyncCmd() *cobra.Command {
+	opts := &SyncOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Keep a private fork in sync with its upstream",
+		Long: heredoc.Doc(`
+			Fetch the latest commits and tags from a private fork's upstream
+			repository and mirror-push them into the fork.
+
+			With no flags, syncs the current directory's fork using its
+			"upstream" remote. Pass --source OWNER/REPO to sync a fork recorded
+			in the local registry without needing a clone on disk, or --all to
+			sync every recorded fork.
+
+			Pass --interval to keep running, re-syncing every N minutes instead
+			of exiting after one pass.
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return syncRun(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Source, "source", "", "Sync the fork of OWNER/REPO, looked up in the local fork registry")
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Sync every fork recorded in the local fork registry")
+	cmd.Flags().IntVar(&opts.Interval, "interval", 0, "Re-run the sync every N minutes instead of exiting after one pass")
+
+	return cmd
+}
+
+func syncRun(opts *SyncOptions) error {
+	for {
+		if err := syncOnce(opts); err != nil {
+			return err
+		}
+
+		if opts.Interval <= 0 {
+			return nil
+		}
+
+		fmt.Printf("Sleeping %d minute(s) until next sync...\n", opts.Interval)
+		time.Sleep(time.Duration(opts.Interval) * time.Minute)
+	}
+}
+
+func syncOnce(opts *SyncOptions) error {
+	if opts.All {
+		records, err := loadForkRecords()
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			fmt.Println("No forks recorded, nothing to sync.")
+			return nil
+		}
+		for _, rec := range records {
+			if err := syncFork(rec); err != nil {
+				return fmt.Errorf("failed to sync %s: %w", rec.Dest, err)
+			}
+		}
+		return nil
+	}
+
+	rec, err := resolveForkRecord(opts.Source)
+	if err != nil {
+		return err
+	}
+	return syncFork(rec)
+}
+
+// resolveForkRecord finds the ForkRecord to sync from --source, or falls
+// back to the current directory's "upstream"/"origin" remotes.
+func resolveForkRecord(source string) (ForkRecord, error) {
+	if source != "" {
+		records, err := loadForkRecords()
+		if err != nil {
+			return ForkRecord{}, err
+		}
+		for _, rec := range records {
+			if rec.Source == source {
+				return rec, nil
+			}
+		}
+		return ForkRecord{}, fmt.Errorf("no recorded fork found for source %q; check the OWNER/REPO or run without --source from a fork's clone", source)
+	}
+
+	upstreamOut, err := exec.Command("git", "remote", "get-url", "upstream").Output()
+	if err != nil {
+		return ForkRecord{}, fmt.Errorf("no --source given and no \"upstream\" remote found in the current directory: %w", err)
+	}
+	upstreamURL := strings.TrimSpace(string(upstreamOut))
+	sourceRepo, err := parseRepository(upstreamURL)
+	if err != nil {
+		return ForkRecord{}, err
+	}
+
+	originOut, err := exec.Command("git", "remote", "get-url", "origin").Output()
+	if err != nil {
+		return ForkRecord{}, fmt.Errorf("no \"origin\" remote found in the current directory: %w", err)
+	}
+	destRepo, err := parseRepository(strings.TrimSpace(string(originOut)))
+	if err != nil {
+		return ForkRecord{}, err
+	}
+
+	return ForkRecord{Source: sourceRepo, Dest: destRepo, Forge: forgeFromHost(upstreamURL)}, nil
+}
+
+// syncFork fetches upstream and mirror-pushes it into rec.Dest, using a
+// bare working directory under ~/.cache/gh-private-fork so repeated syncs
+// don't require a full clone every time.
+func syncFork(rec ForkRecord) error {
+	forge, err := newForgeProvider(rec.Forge)
+	if err != nil {
+		return err
+	}
+
+	srcOwner, srcName, _ := strings.Cut(rec.Source, "/")
+	destOwner, destName, _ := strings.Cut(rec.Dest, "/")
+	upstreamURL := forge.RepoHTTPURL(srcOwner, srcName)
+	originURL := forge.RepoURL(destOwner, destName)
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return fmt.Errorf("unable to determine cache directory: %w", err)
+	}
+	workDir := filepath.Join(cacheDir, "gh-private-fork", srcOwner, srcName+".git")
+
+	if _, err := os.Stat(workDir); os.IsNotExist(err) {
+		fmt.Printf("Creating mirror working directory for %s...\n", rec.Source)
+		if err := os.MkdirAll(filepath.Dir(workDir), 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(workDir), err)
+		}
+		if err := execGit("clone", "--bare", upstreamURL, workDir); err != nil {
+			return fmt.Errorf("failed to create mirror clone: %w", err)
+		}
+		if err := execGit("-C", workDir, "remote", "rename", "origin", "upstream"); err != nil {
+			return fmt.Errorf("failed to rename origin remote: %w", err)
+		}
+		// A bare clone configures no fetch refspec, so without one
+		// `git fetch upstream` would update tags but never branches.
+		if err := execGit("-C", workDir, "config", "remote.upstream.fetch", "+refs/heads/*:refs/heads/*"); err != nil {
+			return fmt.Errorf("failed to configure upstream fetch refspec: %w", err)
+		}
+		if err := execGit("-C", workDir, "remote", "add", "origin", originURL); err != nil {
+			return fmt.Errorf("failed to add origin remote: %w", err)
+		}
+	}
+
+	fmt.Printf("Syncing %s from %s...\n", rec.Dest, rec.Source)
+	if err := execGit("-C", workDir, "fetch", "upstream", "--prune", "--tags"); err != nil {
+		return fmt.Errorf("failed to fetch upstream: %w", err)
+	}
+	if err := execGit("-C", workDir, "push", "origin", "--mirror"); err != nil {
+		return fmt.Errorf("failed to push mirror: %w", err)
+	}
+
+	return nil
+}